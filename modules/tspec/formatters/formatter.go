@@ -0,0 +1,15 @@
+// Package formatters holds the data reported to a tspec test formatter
+// as steps are matched and run.
+package formatters
+
+import "regexp"
+
+// StepDefinition carries the details of a registered step definition that
+// a formatter needs to report a match: the original handler, the
+// expression it was registered with, and the Args captured from the last
+// step it matched.
+type StepDefinition struct {
+	Handler interface{}
+	Expr    *regexp.Regexp
+	Args    []interface{}
+}
@@ -0,0 +1,112 @@
+// Package models holds the internal, executable representation of a
+// registered tspec step definition.
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/gruntwork-io/terratest/modules/tspec/formatters"
+)
+
+// Keyword identifies the Gherkin keyword a StepDefinition is bound to.
+//
+// Its values intentionally line up with tspec.Keyword (Any, Given, When,
+// Then) so callers in the tspec package can pass their Keyword values
+// through unconverted.
+type Keyword int
+
+// Keyword values a StepDefinition may be registered under.
+const (
+	Any Keyword = iota
+	Given
+	When
+	Then
+)
+
+// String returns the Gherkin keyword name, used by matchStep's undefined-
+// step error to say which keyword a step was resolved to.
+func (k Keyword) String() string {
+	switch k {
+	case Given:
+		return "Given"
+	case When:
+		return "When"
+	case Then:
+		return "Then"
+	default:
+		return "Any"
+	}
+}
+
+// errorInterface is the reflect.Type of the error interface.
+var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+
+// StepDefinition is a registered step definition: the reporting details
+// in formatters.StepDefinition plus the reflection metadata needed to
+// invoke its handler and to match it against a step.
+type StepDefinition struct {
+	formatters.StepDefinition
+
+	// HandlerValue is the reflect.Value of the registered handler func.
+	HandlerValue reflect.Value
+
+	// Nested marks a handler that returns []string (a multistep): Run
+	// returns the slice as its result instead of treating it as an error.
+	Nested bool
+
+	// HasContext marks a handler with the contextualized signature
+	// func(context.Context, ...) (context.Context, error).
+	HasContext bool
+
+	// Property marks a handler registered via tspec.Property, run
+	// repeatedly under a shrinking property-based engine instead of once.
+	Property bool
+
+	// Keyword restricts which pickle keyword type this definition
+	// matches. Any matches regardless of keyword.
+	Keyword Keyword
+}
+
+// Run invokes the definition's handler with ctx (only used if HasContext)
+// and args, and returns the context to propagate to the next step or
+// hook, the handler's non-error result (a []string for a Nested
+// multistep, nil otherwise) and any error.
+//
+// A handler declaring no results is treated as always succeeding, since
+// it is expected to fail the step by panicking (e.g. via require/assert)
+// rather than by returning an error.
+func (sd *StepDefinition) Run(ctx context.Context, args []reflect.Value) (context.Context, interface{}, error) {
+	in := args
+	if sd.HasContext {
+		in = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+
+	out := sd.HandlerValue.Call(in)
+
+	if sd.HasContext {
+		nextCtx, ok := out[0].Interface().(context.Context)
+		if !ok {
+			return ctx, nil, fmt.Errorf("tspec: contextualized handler returned a nil context.Context")
+		}
+
+		err, _ := out[1].Interface().(error)
+
+		return nextCtx, nil, err
+	}
+
+	if len(out) == 0 {
+		return ctx, nil, nil
+	}
+
+	result := out[0]
+
+	if sd.Nested {
+		return ctx, result.Interface(), nil
+	}
+
+	err, _ := result.Interface().(error)
+
+	return ctx, nil, err
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStepDefinitionRunNoReturn(t *testing.T) {
+	called := false
+	sd := &StepDefinition{HandlerValue: reflect.ValueOf(func() { called = true })}
+
+	ctx, result, err := sd.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if result != nil {
+		t.Fatalf("expected nil result, got: %v", result)
+	}
+	if ctx == nil {
+		t.Fatal("expected the input context to be returned unchanged")
+	}
+	if !called {
+		t.Fatal("expected handler to be called")
+	}
+}
+
+func TestStepDefinitionRunError(t *testing.T) {
+	wantErr := errors.New("boom")
+	sd := &StepDefinition{HandlerValue: reflect.ValueOf(func() error { return wantErr })}
+
+	_, _, err := sd.Run(context.Background(), nil)
+	if err != wantErr {
+		t.Fatalf("expected %v, got: %v", wantErr, err)
+	}
+}
+
+func TestStepDefinitionRunNested(t *testing.T) {
+	sd := &StepDefinition{
+		Nested:       true,
+		HandlerValue: reflect.ValueOf(func() []string { return []string{"a step", "another step"} }),
+	}
+
+	_, result, err := sd.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	steps, ok := result.([]string)
+	if !ok || len(steps) != 2 {
+		t.Fatalf("expected a 2-element []string result, got: %#v", result)
+	}
+}
+
+func TestStepDefinitionRunContextualized(t *testing.T) {
+	type ctxKey struct{}
+
+	sd := &StepDefinition{
+		HasContext: true,
+		HandlerValue: reflect.ValueOf(func(ctx context.Context, name string) (context.Context, error) {
+			return context.WithValue(ctx, ctxKey{}, name), nil
+		}),
+	}
+
+	ctx, _, err := sd.Run(context.Background(), []reflect.Value{reflect.ValueOf("alice")})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got := ctx.Value(ctxKey{}); got != "alice" {
+		t.Fatalf("expected the handler's returned context to propagate, got: %v", got)
+	}
+}
@@ -0,0 +1,591 @@
+package tspec
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cucumber/messages-go/v10"
+	"pgregory.net/rapid"
+
+	"github.com/gruntwork-io/terratest/modules/tspec/internal/models"
+)
+
+// tspecRapidChecks lets PropertyChecks be overridden from the command line,
+// under the tspec namespace so specs can tune case counts independently of
+// other rapid consumers linked into the same test binary. It is applied to
+// rapid's own -rapid.checks flag (best-effort: if that flag isn't
+// registered in this binary, rapid.Check falls back to its own default).
+var tspecRapidChecks = flag.Int("tspec.rapid.checks", PropertyChecks, "number of generated cases to run each Property step with")
+
+// setRapidChecks points rapid at n checks for the duration of a single
+// Property step by setting its -rapid.checks flag (the only way rapid.Check
+// takes a check count), and returns a func restoring whatever value that
+// flag held before, so a property step run in a test binary shared with
+// other rapid consumers doesn't leave it clobbered.
+func setRapidChecks(n int) (restore func()) {
+	f := flag.Lookup("rapid.checks")
+	if f == nil {
+		return func() {}
+	}
+
+	prev := f.Value.String()
+	_ = flag.Set("rapid.checks", strconv.Itoa(n))
+
+	return func() { _ = flag.Set("rapid.checks", prev) }
+}
+
+// errorInterface is the reflect.Type of the error interface, used by
+// test_context.go to validate handler and hook return types.
+var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+
+// ErrUndefined is returned when running a step for which no registered
+// *StepDefinition matches.
+var ErrUndefined = errors.New("step is undefined")
+
+// suite holds the steps and hooks registered against a ScenarioContext
+// for a single InitializeScenario call, and backs the suite field every
+// ScenarioContext carries.
+type suite struct {
+	steps []*models.StepDefinition
+
+	beforeScenarioHandlers []BeforeScenarioHook
+	afterScenarioHandlers  []AfterScenarioHook
+	beforeStepHandlers     []BeforeStepHook
+	afterStepHandlers      []AfterStepHook
+
+	// astKeywords maps a Gherkin step's id (a pickle step's AstNodeIds
+	// entry) to its resolved keyword. Pickle steps don't carry their
+	// keyword directly, so it is looked up here instead; see
+	// registerGherkinKeywords.
+	astKeywords map[string]Keyword
+}
+
+// composeSuite merges tsc's suite-level steps and hooks -- which persist
+// for the whole run -- with sc's scenario-level ones into the single
+// *suite used to run one scenario. Before-hooks run outermost-suite
+// first, after-hooks run outermost-suite last, giving the ordering
+// suite-before -> scenario-before -> step-before -> step -> step-after ->
+// scenario-after -> suite-after. Scenario-level step definitions are
+// tried before suite-level ones, so a scenario can override a suite-wide
+// step with the same expression.
+func composeSuite(tsc *TestSuiteContext, sc *suite) *suite {
+	if tsc == nil {
+		return sc
+	}
+
+	return &suite{
+		steps: append(append([]*models.StepDefinition{}, sc.steps...), tsc.steps...),
+
+		beforeScenarioHandlers: append(append([]BeforeScenarioHook{}, tsc.beforeScenarioHandlers...), sc.beforeScenarioHandlers...),
+		afterScenarioHandlers:  append(append([]AfterScenarioHook{}, sc.afterScenarioHandlers...), tsc.afterScenarioHandlers...),
+		beforeStepHandlers:     append(append([]BeforeStepHook{}, tsc.beforeStepHandlers...), sc.beforeStepHandlers...),
+		afterStepHandlers:      append(append([]AfterStepHook{}, sc.afterStepHandlers...), tsc.afterStepHandlers...),
+
+		astKeywords: sc.astKeywords,
+	}
+}
+
+// RunScenario runs scenario's steps against the combination of tsc's
+// suite-level steps and hooks and sc's scenario-level ones (typically
+// registered via a single InitializeScenario call), in the order
+// documented on composeSuite.
+//
+// doc is the parsed feature this scenario was compiled from, used to
+// resolve each step's Given/When/Then keyword via registerGherkinKeywords
+// so Keyword-scoped step definitions (ScenarioContext.Given/When/Then) can
+// match; pass nil if no document is available, and every step is matched
+// against Any-keyword definitions only, same as before this resolution
+// existed.
+func (tsc *TestSuiteContext) RunScenario(ctx context.Context, sc *ScenarioContext, doc *messages.GherkinDocument, scenario *Scenario, steps []*Step) (context.Context, error) {
+	s := composeSuite(tsc, sc.suite)
+
+	astKeywords := map[string]Keyword{}
+	registerGherkinKeywords(doc, astKeywords)
+	s.astKeywords = astKeywords
+
+	return s.runScenario(ctx, scenario, steps)
+}
+
+// runScenario runs sc's steps against suite's registered hooks and step
+// definitions, threading a single context.Context through the whole
+// scenario: the context produced by the last BeforeScenario hook (or the
+// root ctx if there are none) is fed into the first step or BeforeStep
+// hook, each step/hook's returned context is fed into the next, and the
+// context produced by the scenario's last step or hook is finally fed
+// into AfterScenario.
+func (s *suite) runScenario(ctx context.Context, sc *Scenario, steps []*Step) (context.Context, error) {
+	var err error
+
+	for _, h := range s.beforeScenarioHandlers {
+		if ctx, err = h(ctx, sc); err != nil {
+			return s.afterScenario(ctx, sc, err)
+		}
+	}
+
+	for _, st := range steps {
+		ctx, err = s.runStep(ctx, st)
+		if err != nil {
+			break
+		}
+	}
+
+	return s.afterScenario(ctx, sc, err)
+}
+
+func (s *suite) afterScenario(ctx context.Context, sc *Scenario, err error) (context.Context, error) {
+	for _, h := range s.afterScenarioHandlers {
+		var hookErr error
+		if ctx, hookErr = h(ctx, sc, err); hookErr != nil {
+			err = hookErr
+		}
+	}
+
+	return ctx, err
+}
+
+// runStep runs a single step: its BeforeStep hooks, the matched
+// *StepDefinition, and its AfterStep hooks, in that order, threading ctx
+// through each in turn.
+func (s *suite) runStep(ctx context.Context, st *Step) (context.Context, error) {
+	var err error
+
+	for _, h := range s.beforeStepHandlers {
+		if ctx, err = h(ctx, st); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		ctx, err = s.matchAndRun(ctx, st)
+	}
+
+	for _, h := range s.afterStepHandlers {
+		var hookErr error
+		if ctx, hookErr = h(ctx, st, err); hookErr != nil {
+			err = hookErr
+		}
+	}
+
+	return ctx, err
+}
+
+// matchAndRun finds the first *StepDefinition whose expression and
+// keyword match st and runs it, returning ErrUndefined if none match. A
+// panic raised while running the step is recovered and returned as the
+// step's error, with ctx returned unchanged.
+func (s *suite) matchAndRun(ctx context.Context, st *Step) (next context.Context, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			next, err = ctx, fmt.Errorf("tspec: step %q panicked: %v", stepText(st), r)
+		}
+	}()
+
+	def, args, err := matchStep(st, resolveStepKeyword(st, s.astKeywords), s.steps)
+	if err != nil {
+		return ctx, err
+	}
+
+	if def.Property {
+		return ctx, runProperty(ctx, def, args)
+	}
+
+	ctx, _, err = def.Run(ctx, args)
+
+	return ctx, err
+}
+
+// contextTKey is the context.Context key a *testing.T is stashed under by
+// WithT, for Property steps to drive rapid's generate-and-shrink engine.
+type contextTKey struct{}
+
+// WithT returns a copy of ctx carrying t. A scenario's context must be
+// wrapped with WithT before it reaches RunScenario for that scenario to be
+// able to run any Property step; steps that aren't Property steps never
+// look at it.
+func WithT(ctx context.Context, t *testing.T) context.Context {
+	return context.WithValue(ctx, contextTKey{}, t)
+}
+
+// runProperty runs def -- a handler registered via ScenarioContext.Property
+// -- PropertyChecks times (overridable with -tspec.rapid.checks) against
+// freshly generated cases, shrinking and reporting the minimal failing case
+// through t on failure. args are the handler's regex-captured arguments,
+// computed once and passed unchanged to every case; only the leading
+// *rapid.T argument varies per case.
+func runProperty(ctx context.Context, def *models.StepDefinition, args []reflect.Value) error {
+	t, ok := ctx.Value(contextTKey{}).(*testing.T)
+	if !ok {
+		return fmt.Errorf("tspec: a Property step requires a *testing.T in context; wrap the scenario's context with tspec.WithT")
+	}
+
+	checks := PropertyChecks
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "tspec.rapid.checks" {
+			checks = *tspecRapidChecks
+		}
+	})
+
+	restore := setRapidChecks(checks)
+	defer restore()
+
+	var stepErr error
+	rapid.Check(t, func(rt *rapid.T) {
+		in := append([]reflect.Value{reflect.ValueOf(rt)}, args...)
+		out := def.HandlerValue.Call(in)
+
+		if len(out) == 1 {
+			if err, _ := out[0].Interface().(error); err != nil {
+				stepErr = err
+				rt.Fatalf("%v", err)
+			}
+		}
+	})
+
+	return stepErr
+}
+
+// matchStep returns the first *StepDefinition among defs whose Expr
+// matches st's text and whose Keyword is Any or equal to keyword, along
+// with the matched groups converted to the handler's argument types. If
+// none match, the returned error wraps ErrUndefined and names the
+// resolved keyword and step text.
+func matchStep(st *Step, keyword Keyword, defs []*models.StepDefinition) (*models.StepDefinition, []reflect.Value, error) {
+	text := stepText(st)
+
+	for _, def := range defs {
+		if def.Keyword != Any && def.Keyword != keyword {
+			continue
+		}
+
+		m := def.Expr.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+
+		args, err := buildArgs(def, m[1:], st)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return def, args, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: no step definition matches %s %q", ErrUndefined, keyword, text)
+}
+
+// resolveStepKeyword looks up st's resolved Gherkin keyword in
+// astKeywords via its AstNodeIds, defaulting to Any when none is found
+// (e.g. in tests that build a *Step without a backing Gherkin document).
+func resolveStepKeyword(st *Step, astKeywords map[string]Keyword) Keyword {
+	for _, id := range st.AstNodeIds {
+		if kw, ok := astKeywords[id]; ok {
+			return kw
+		}
+	}
+
+	return Any
+}
+
+// registerGherkinKeywords walks doc's features and records, for every
+// step id, its resolved keyword: Given/When/Then directly, and the
+// preceding concrete keyword for And/But/*, mirroring Gherkin's own
+// keyword resolution rules. The result is merged into astKeywords so a
+// suite can match pickle steps compiled from doc against Given/When/Then
+// -scoped step definitions.
+func registerGherkinKeywords(doc *messages.GherkinDocument, astKeywords map[string]Keyword) {
+	if doc == nil || doc.Feature == nil {
+		return
+	}
+
+	for _, child := range doc.Feature.Children {
+		if background := child.GetBackground(); background != nil {
+			registerStepKeywords(background.Steps, astKeywords)
+		}
+		if scenario := child.GetScenario(); scenario != nil {
+			registerStepKeywords(scenario.Steps, astKeywords)
+		}
+		if rule := child.GetRule(); rule != nil {
+			for _, ruleChild := range rule.Children {
+				if background := ruleChild.GetBackground(); background != nil {
+					registerStepKeywords(background.Steps, astKeywords)
+				}
+				if scenario := ruleChild.GetScenario(); scenario != nil {
+					registerStepKeywords(scenario.Steps, astKeywords)
+				}
+			}
+		}
+	}
+}
+
+func registerStepKeywords(steps []*messages.GherkinDocument_Feature_Step, astKeywords map[string]Keyword) {
+	last := Any
+
+	for _, step := range steps {
+		kw := gherkinKeyword(step.Keyword)
+		if kw == Any {
+			kw = last // And/But/* inherit the preceding concrete keyword
+		} else {
+			last = kw
+		}
+
+		astKeywords[step.Id] = kw
+	}
+}
+
+// gherkinKeyword maps a Gherkin step's literal keyword text to a Keyword,
+// returning Any for And/But/* or a translated keyword this package
+// doesn't special-case.
+func gherkinKeyword(text string) Keyword {
+	switch strings.TrimSpace(text) {
+	case "Given":
+		return Given
+	case "When":
+		return When
+	case "Then":
+		return Then
+	default:
+		return Any
+	}
+}
+
+// stepText returns st's matchable text.
+func stepText(st *Step) string {
+	return st.Text
+}
+
+// buildArgs converts a matched step's regex capture groups into
+// reflect.Values matching def's handler parameter types, skipping the
+// leading context.Context parameter for a contextualized handler.
+func buildArgs(def *models.StepDefinition, captures []string, st *Step) ([]reflect.Value, error) {
+	typ := def.HandlerValue.Type()
+
+	start := 0
+	if def.HasContext {
+		start = 1
+	}
+	if def.Property {
+		start = 1 // the leading *rapid.T is supplied by the property runner, not matched here
+	}
+
+	numArgs := typ.NumIn() - start
+	args := make([]reflect.Value, 0, numArgs)
+
+	captureIdx := 0
+
+	for i := 0; i < numArgs; i++ {
+		target := typ.In(start + i)
+
+		v, consumesCapture, err := convertArg(target, captures, captureIdx, st)
+		if err != nil {
+			return nil, err
+		}
+		if consumesCapture {
+			captureIdx++
+		}
+
+		args = append(args, v)
+	}
+
+	return args, nil
+}
+
+// convertArg converts a single handler argument of type target, either
+// from st's DocString/Table argument, from st's Table decoded into a
+// struct or slice, or from the next unconsumed capture group, and reports
+// whether it consumed a capture group.
+func convertArg(target reflect.Type, captures []string, captureIdx int, st *Step) (reflect.Value, bool, error) {
+	switch target {
+	case docStringType:
+		return reflect.ValueOf(docStringOf(st)), false, nil
+	case tableType:
+		return reflect.ValueOf(tableOf(st)), false, nil
+	case bigIntType, bigFloatType, apdDecimalType:
+		// Handled like any other scalar, below; excluded here only so
+		// the struct/slice-from-Table branch doesn't mistake *big.Int,
+		// *big.Float or *apd.Decimal (all struct pointers) for one.
+	default:
+		if tbl := tableOf(st); tbl != nil {
+			switch {
+			case target.Kind() == reflect.Ptr && target.Elem().Kind() == reflect.Struct:
+				v, err := decodeTableStruct(tbl, target)
+				return v, false, err
+			case target.Kind() == reflect.Slice && target.Elem().Kind() != reflect.Uint8:
+				v, err := decodeTableSlice(tbl, target)
+				return v, false, err
+			}
+		}
+	}
+
+	if captureIdx >= len(captures) {
+		return reflect.Value{}, false, fmt.Errorf("tspec: handler expects more arguments than the step matched")
+	}
+
+	v, err := convertScalarArg(captures[captureIdx], target)
+
+	return v, true, err
+}
+
+// docStringType and tableType are the reflect.Types of *tspec.DocString
+// and *tspec.Table, recognized as special-cased step handler arguments.
+// bigIntType, bigFloatType and apdDecimalType are recognized by
+// convertScalarArg as arbitrary-precision numeric arguments.
+var (
+	docStringType  = reflect.TypeOf((*DocString)(nil))
+	tableType      = reflect.TypeOf((*Table)(nil))
+	bigIntType     = reflect.TypeOf((*big.Int)(nil))
+	bigFloatType   = reflect.TypeOf((*big.Float)(nil))
+	apdDecimalType = reflect.TypeOf((*apd.Decimal)(nil))
+)
+
+// docStringOf and tableOf extract the DocString/Table argument from a
+// step, if any. PickleStepArgument stores them behind a oneof, so they're
+// read through the generated Get* accessors rather than a field.
+func docStringOf(st *Step) *DocString {
+	if st.Argument == nil {
+		return nil
+	}
+
+	return st.Argument.GetDocString()
+}
+
+func tableOf(st *Step) *Table {
+	if st.Argument == nil {
+		return nil
+	}
+
+	return st.Argument.GetDataTable()
+}
+
+// decodeTableStruct populates a new value of target (a struct pointer)
+// from tbl, matching tbl's header row against the struct's `tspec:"name"`
+// field tags; tbl's second row supplies the values. Columns without a
+// matching tagged field are ignored.
+func decodeTableStruct(tbl *Table, target reflect.Type) (reflect.Value, error) {
+	if len(tbl.Rows) < 2 {
+		return reflect.Value{}, fmt.Errorf("tspec: decoding %s needs a table with a header row and a data row", target)
+	}
+
+	headers, values := tbl.Rows[0].Cells, tbl.Rows[1].Cells
+	if len(headers) != len(values) {
+		return reflect.Value{}, fmt.Errorf("tspec: decoding %s: table header/row column count mismatch", target)
+	}
+
+	out := reflect.New(target.Elem())
+
+	for i, header := range headers {
+		field, ok := tableFieldByTag(target.Elem(), header.Value)
+		if !ok {
+			continue
+		}
+
+		v, err := convertScalarArg(values[i].Value, field.Type)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("tspec: decoding %s: column %q: %w", target, header.Value, err)
+		}
+
+		out.Elem().FieldByIndex(field.Index).Set(v)
+	}
+
+	return out, nil
+}
+
+// tableFieldByTag returns the field of elem (a struct type) tagged
+// `tspec:"name"`, if any.
+func tableFieldByTag(elem reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < elem.NumField(); i++ {
+		if field := elem.Field(i); field.Tag.Get("tspec") == name {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// decodeTableSlice converts tbl -- which must have exactly one column --
+// into a []T, converting every row's single cell the same way a regex
+// capture group would be.
+func decodeTableSlice(tbl *Table, target reflect.Type) (reflect.Value, error) {
+	elemTyp := target.Elem()
+	out := reflect.MakeSlice(target, 0, len(tbl.Rows))
+
+	for _, row := range tbl.Rows {
+		if len(row.Cells) != 1 {
+			return reflect.Value{}, fmt.Errorf("tspec: decoding %s needs a single-column table, got %d columns", target, len(row.Cells))
+		}
+
+		v, err := convertScalarArg(row.Cells[0].Value, elemTyp)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("tspec: decoding %s: %w", target, err)
+		}
+
+		out = reflect.Append(out, v)
+	}
+
+	return out, nil
+}
+
+// convertScalarArg converts a single regex capture group into a value of
+// type target: an int/float kind, string, []byte, *big.Int, *big.Float,
+// *apd.Decimal, or any type with a Transformer registered for it.
+func convertScalarArg(capture string, target reflect.Type) (reflect.Value, error) {
+	switch target {
+	case bigIntType:
+		n, ok := new(big.Int).SetString(capture, 10)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("tspec: cannot convert %q to *big.Int", capture)
+		}
+		return reflect.ValueOf(n), nil
+	case bigFloatType:
+		f, ok := new(big.Float).SetString(capture)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("tspec: cannot convert %q to *big.Float", capture)
+		}
+		return reflect.ValueOf(f), nil
+	case apdDecimalType:
+		d, _, err := apd.NewFromString(capture)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("tspec: cannot convert %q to *apd.Decimal: %w", capture, err)
+		}
+		return reflect.ValueOf(d), nil
+	}
+
+	if fn, ok := transformers[target]; ok {
+		out := fn.Call([]reflect.Value{reflect.ValueOf(capture)})
+		if err, _ := out[1].Interface().(error); err != nil {
+			return reflect.Value{}, fmt.Errorf("tspec: cannot convert %q to %s: %w", capture, target, err)
+		}
+		return out[0], nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(capture).Convert(target), nil
+	case reflect.Slice:
+		if target.Elem().Kind() != reflect.Uint8 {
+			return reflect.Value{}, fmt.Errorf("tspec: unsupported handler argument type: %s", target)
+		}
+		return reflect.ValueOf([]byte(capture)), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(capture, 10, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("tspec: cannot convert %q to %s: %w", capture, target, err)
+		}
+		return reflect.ValueOf(n).Convert(target), nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(capture, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("tspec: cannot convert %q to %s: %w", capture, target, err)
+		}
+		return reflect.ValueOf(f).Convert(target), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("tspec: unsupported handler argument type: %s", target)
+}
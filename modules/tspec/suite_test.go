@@ -0,0 +1,417 @@
+package tspec
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cucumber/messages-go/v10"
+	"pgregory.net/rapid"
+)
+
+type ctxKey string
+
+func TestRunScenarioThreadsContextThroughHooksAndSteps(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	sc.BeforeScenario(func(ctx context.Context, _ *Scenario) (context.Context, error) {
+		return context.WithValue(ctx, ctxKey("seen"), []string{"before-scenario"}), nil
+	})
+
+	sc.Step(`^a step$`, func(ctx context.Context) (context.Context, error) {
+		seen := ctx.Value(ctxKey("seen")).([]string)
+		return context.WithValue(ctx, ctxKey("seen"), append(seen, "step")), nil
+	})
+
+	var finalSeen []string
+	sc.AfterScenario(func(ctx context.Context, _ *Scenario, err error) (context.Context, error) {
+		finalSeen = ctx.Value(ctxKey("seen")).([]string)
+		return ctx, err
+	})
+
+	steps := []*Step{{Text: "a step"}}
+
+	_, err := sc.suite.runScenario(context.Background(), &messages.Pickle{}, steps)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	want := []string{"before-scenario", "step"}
+	if len(finalSeen) != len(want) || finalSeen[0] != want[0] || finalSeen[1] != want[1] {
+		t.Fatalf("expected context to thread through before-scenario -> step -> after-scenario, got: %v", finalSeen)
+	}
+}
+
+func TestRunScenarioPropagatesStepError(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	wantErr := errors.New("boom")
+	sc.Step(`^a failing step$`, func() error { return wantErr })
+
+	var afterErr error
+	sc.AfterScenario(func(scn *Scenario, err error) {
+		afterErr = err
+	})
+
+	steps := []*Step{{Text: "a failing step"}}
+
+	_, err := sc.suite.runScenario(context.Background(), &messages.Pickle{}, steps)
+	if err != wantErr {
+		t.Fatalf("expected %v, got: %v", wantErr, err)
+	}
+	if afterErr != wantErr {
+		t.Fatalf("expected AfterScenario to observe %v, got: %v", wantErr, afterErr)
+	}
+}
+
+func TestMatchStepUndefined(t *testing.T) {
+	s := &suite{}
+
+	_, _, err := matchStep(&Step{Text: "a step nobody registered"}, Any, s.steps)
+	if !errors.Is(err, ErrUndefined) {
+		t.Fatalf("expected ErrUndefined, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Any") || !strings.Contains(err.Error(), "a step nobody registered") {
+		t.Fatalf("expected the error to name the keyword and step text, got: %v", err)
+	}
+}
+
+func TestMatchStepCapturesStringArg(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	var got string
+	sc.Step(`^a user "([^"]*)"$`, func(name string) error {
+		got = name
+		return nil
+	})
+
+	def, args, err := matchStep(&Step{Text: `a user "alice"`}, Any, sc.suite.steps)
+	if err != nil {
+		t.Fatalf("expected a match, got error: %v", err)
+	}
+
+	ctx, _, err := def.Run(context.Background(), args)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if ctx == nil {
+		t.Fatal("expected a context back")
+	}
+	if got != "alice" {
+		t.Fatalf("expected captured arg %q, got: %q", "alice", got)
+	}
+}
+
+func TestMatchStepFiltersByKeyword(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	var ran string
+	sc.Given(`^a user "([^"]*)"$`, func(name string) error { ran = "given"; return nil })
+	sc.Then(`^a user "([^"]*)"$`, func(name string) error { ran = "then"; return nil })
+
+	st := &Step{Text: `a user "alice"`, AstNodeIds: []string{"s1"}}
+
+	def, args, err := matchStep(st, Then, sc.suite.steps)
+	if err != nil {
+		t.Fatalf("expected a match, got error: %v", err)
+	}
+	if _, _, err := def.Run(context.Background(), args); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if ran != "then" {
+		t.Fatalf("expected the Then definition to run for a Then step, got: %q", ran)
+	}
+
+	def, args, err = matchStep(st, Given, sc.suite.steps)
+	if err != nil {
+		t.Fatalf("expected a match, got error: %v", err)
+	}
+	if _, _, err := def.Run(context.Background(), args); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if ran != "given" {
+		t.Fatalf("expected the Given definition to run for a Given step, got: %q", ran)
+	}
+}
+
+func TestResolveStepKeywordDefaultsToAny(t *testing.T) {
+	st := &Step{Text: "a step", AstNodeIds: []string{"unknown-id"}}
+
+	if kw := resolveStepKeyword(st, map[string]Keyword{"other-id": Then}); kw != Any {
+		t.Fatalf("expected Any when the step's ast node id isn't cached, got: %v", kw)
+	}
+}
+
+func TestResolveStepKeywordFromCache(t *testing.T) {
+	st := &Step{Text: "a step", AstNodeIds: []string{"s1"}}
+
+	if kw := resolveStepKeyword(st, map[string]Keyword{"s1": Given}); kw != Given {
+		t.Fatalf("expected Given, got: %v", kw)
+	}
+}
+
+func TestRunScenarioComposesSuiteAndScenarioHooksInOrder(t *testing.T) {
+	var order []string
+	record := func(name string) { order = append(order, name) }
+
+	tsc := &TestSuiteContext{}
+	tsc.BeforeScenario(func(sc *Scenario) { record("suite-before") })
+	tsc.BeforeStep(func(st *Step) { record("suite-step-before") })
+	tsc.AfterStep(func(st *Step, err error) { record("suite-step-after") })
+	tsc.AfterScenario(func(sc *Scenario, err error) { record("suite-after") })
+
+	sc := &ScenarioContext{suite: &suite{}}
+	sc.BeforeScenario(func(scn *Scenario) { record("scenario-before") })
+	sc.BeforeStep(func(st *Step) { record("scenario-step-before") })
+	sc.Step(`^a step$`, func() { record("step") })
+	sc.AfterStep(func(st *Step, err error) { record("scenario-step-after") })
+	sc.AfterScenario(func(scn *Scenario, err error) { record("scenario-after") })
+
+	_, err := tsc.RunScenario(context.Background(), sc, nil, &messages.Pickle{}, []*Step{{Text: "a step"}})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	want := []string{
+		"suite-before", "scenario-before",
+		"suite-step-before", "scenario-step-before",
+		"step",
+		"scenario-step-after", "suite-step-after",
+		"scenario-after", "suite-after",
+	}
+
+	if len(order) != len(want) {
+		t.Fatalf("expected order %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, order)
+		}
+	}
+}
+
+func TestRunScenarioMatchesStepsByResolvedGherkinKeyword(t *testing.T) {
+	tsc := &TestSuiteContext{}
+	sc := &ScenarioContext{suite: &suite{}}
+
+	var ran string
+	sc.Given(`^a widget$`, func() error { ran = "given"; return nil })
+	sc.Then(`^a widget$`, func() error { ran = "then"; return nil })
+
+	doc := &messages.GherkinDocument{
+		Feature: &messages.GherkinDocument_Feature{
+			Children: []*messages.GherkinDocument_Feature_FeatureChild{
+				{Value: &messages.GherkinDocument_Feature_FeatureChild_Scenario{
+					Scenario: &messages.GherkinDocument_Feature_Scenario{
+						Steps: []*messages.GherkinDocument_Feature_Step{
+							{Id: "s1", Keyword: "Then "},
+						},
+					},
+				}},
+			},
+		},
+	}
+
+	steps := []*Step{{Text: "a widget", AstNodeIds: []string{"s1"}}}
+
+	if _, err := tsc.RunScenario(context.Background(), sc, doc, &messages.Pickle{}, steps); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if ran != "then" {
+		t.Fatalf("expected the step to resolve to Then via the feature document and run the Then definition, got: %q", ran)
+	}
+}
+
+func TestRunScenarioRunsPropertySteps(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	var ran int
+	var sawName string
+	sc.Property(`^a user "([^"]*)" is valid$`, func(rt *rapid.T, name string) {
+		rapid.IntRange(0, 10).Draw(rt, "n")
+		ran++
+		sawName = name
+	})
+
+	ctx := WithT(context.Background(), t)
+	steps := []*Step{{Text: `a user "alice" is valid`}}
+
+	if _, err := sc.suite.runScenario(ctx, &messages.Pickle{}, steps); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if ran == 0 {
+		t.Fatal("expected the property handler to run at least once")
+	}
+	if sawName != "alice" {
+		t.Fatalf("expected the captured arg to reach every case, got: %q", sawName)
+	}
+}
+
+func TestRunScenarioPropertyStepWithoutTRequiresWithT(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	sc.Property(`^a property step$`, func(rt *rapid.T) {})
+
+	steps := []*Step{{Text: "a property step"}}
+
+	_, err := sc.suite.runScenario(context.Background(), &messages.Pickle{}, steps)
+	if err == nil {
+		t.Fatal("expected an error when running a Property step without wrapping the context with WithT")
+	}
+}
+
+func TestConvertScalarArgBigTypes(t *testing.T) {
+	v, err := convertScalarArg("123456789012345678901234567890", bigIntType)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got := v.Interface().(*big.Int).String(); got != "123456789012345678901234567890" {
+		t.Fatalf("expected the full precision value back, got: %s", got)
+	}
+
+	if _, err := convertScalarArg("not a number", bigIntType); err == nil {
+		t.Fatal("expected an error converting a non-numeric capture to *big.Int")
+	}
+
+	v, err = convertScalarArg("3.14", bigFloatType)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if f, _ := v.Interface().(*big.Float).Float64(); f != 3.14 {
+		t.Fatalf("expected 3.14, got: %v", f)
+	}
+
+	v, err = convertScalarArg("2.50", apdDecimalType)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got := v.Interface().(*apd.Decimal).String(); got != "2.50" {
+		t.Fatalf("expected 2.50, got: %s", got)
+	}
+}
+
+func TestConvertScalarArgUsesRegisteredTransformer(t *testing.T) {
+	type widget struct{ name string }
+
+	RegisterTransformer(widget{}, func(capture string) (widget, error) {
+		return widget{name: capture}, nil
+	})
+
+	v, err := convertScalarArg("gadget", reflect.TypeOf(widget{}))
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got := v.Interface().(widget).name; got != "gadget" {
+		t.Fatalf("expected %q, got: %q", "gadget", got)
+	}
+}
+
+func tableRow(values ...string) *messages.PickleStepArgument_PickleTable_PickleTableRow {
+	row := &messages.PickleStepArgument_PickleTable_PickleTableRow{}
+	for _, v := range values {
+		row.Cells = append(row.Cells, &messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{Value: v})
+	}
+	return row
+}
+
+func TestDecodeTableStructMatchesFieldsByTag(t *testing.T) {
+	type account struct {
+		Name   string `tspec:"name"`
+		Budget int    `tspec:"budget"`
+	}
+
+	tbl := &Table{Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+		tableRow("name", "budget"),
+		tableRow("alice", "42"),
+	}}
+
+	v, err := decodeTableStruct(tbl, reflect.TypeOf(&account{}))
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	got := v.Interface().(*account)
+	if got.Name != "alice" || got.Budget != 42 {
+		t.Fatalf("expected {alice 42}, got: %+v", got)
+	}
+}
+
+func TestDecodeTableSliceFromSingleColumn(t *testing.T) {
+	tbl := &Table{Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+		tableRow("alice"),
+		tableRow("bob"),
+	}}
+
+	v, err := decodeTableSlice(tbl, reflect.TypeOf([]string{}))
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	got := v.Interface().([]string)
+	if len(got) != 2 || got[0] != "alice" || got[1] != "bob" {
+		t.Fatalf("expected [alice bob], got: %v", got)
+	}
+}
+
+func TestMatchStepDecodesStructArgFromTable(t *testing.T) {
+	type account struct {
+		Name string `tspec:"name"`
+	}
+
+	sc := &ScenarioContext{suite: &suite{}}
+
+	var got *account
+	sc.Step(`^a new account$`, func(a *account) error {
+		got = a
+		return nil
+	})
+
+	st := &Step{
+		Text: "a new account",
+		Argument: &messages.PickleStepArgument{
+			Message: &messages.PickleStepArgument_DataTable{
+				DataTable: &messages.PickleStepArgument_PickleTable{
+					Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+						tableRow("name"),
+						tableRow("alice"),
+					},
+				},
+			},
+		},
+	}
+
+	def, args, err := matchStep(st, Any, sc.suite.steps)
+	if err != nil {
+		t.Fatalf("expected a match, got error: %v", err)
+	}
+	if _, _, err := def.Run(context.Background(), args); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got == nil || got.Name != "alice" {
+		t.Fatalf("expected the table to decode into {alice}, got: %+v", got)
+	}
+}
+
+func TestRegisterStepKeywordsResolvesAndBut(t *testing.T) {
+	steps := []*messages.GherkinDocument_Feature_Step{
+		{Id: "1", Keyword: "Given "},
+		{Id: "2", Keyword: "And "},
+		{Id: "3", Keyword: "When "},
+		{Id: "4", Keyword: "But "},
+		{Id: "5", Keyword: "Then "},
+	}
+
+	astKeywords := map[string]Keyword{}
+	registerStepKeywords(steps, astKeywords)
+
+	want := map[string]Keyword{"1": Given, "2": Given, "3": When, "4": When, "5": Then}
+	for id, kw := range want {
+		if astKeywords[id] != kw {
+			t.Errorf("step %s: expected %v, got %v", id, kw, astKeywords[id])
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package tspec
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 
 	"github.com/cucumber/messages-go/v10"
+	"pgregory.net/rapid"
 
 	"github.com/gruntwork-io/terratest/modules/tspec/formatters"
 	"github.com/gruntwork-io/terratest/modules/tspec/internal/models"
@@ -51,6 +54,46 @@ type Steps []string
 // or successful
 type StepDefinition = formatters.StepDefinition
 
+// BeforeScenarioHook is a contextualized hook run before every scenario.
+//
+// It receives the context produced by the previous hook in the chain (or
+// the per-scenario root context for the first one) and returns the context
+// that should be fed into the scenario's first step, allowing values such
+// as deadlines, cancelation or per-scenario Terratest module options to be
+// attached without resorting to global state.
+type BeforeScenarioHook func(ctx context.Context, sc *Scenario) (context.Context, error)
+
+// AfterScenarioHook is a contextualized hook run after every scenario.
+//
+// It receives the context produced by the scenario's last step or hook
+// and returns the context that is propagated onward.
+type AfterScenarioHook func(ctx context.Context, sc *Scenario, err error) (context.Context, error)
+
+// BeforeStepHook is a contextualized hook run before every step.
+type BeforeStepHook func(ctx context.Context, st *Step) (context.Context, error)
+
+// AfterStepHook is a contextualized hook run after every step.
+type AfterStepHook func(ctx context.Context, st *Step, err error) (context.Context, error)
+
+// Keyword identifies the Gherkin keyword a step definition is bound to.
+//
+// A definition registered with Any (the default used by Step) matches a
+// step regardless of its pickle keyword type. A definition registered
+// with Given, When or Then only matches a step whose pickle step resolves
+// to that keyword type, which disambiguates a shared regex whose meaning
+// differs by keyword, e.g. `^a user "X"$` under Given creates the user,
+// under Then asserts it exists.
+type Keyword = models.Keyword
+
+// Keyword values accepted by ScenarioContext.Step and the keyword-specific
+// registration methods.
+const (
+	Any   = models.Any
+	Given = models.Given
+	When  = models.When
+	Then  = models.Then
+)
+
 // DocString represents the DocString argument made to a step definition
 type DocString = messages.PickleStepArgument_PickleDocString
 
@@ -66,9 +109,25 @@ type Table = messages.PickleStepArgument_PickleTable
 //
 // Note that all event hooks does not catch panic errors
 // in order to have a trace information
+//
+// Steps and scenario/step hooks registered directly on TestSuiteContext
+// persist for the whole run instead of a single InitializeScenario call:
+// the runner composes them with any handlers registered on the
+// per-scenario ScenarioContext, firing suite-level hooks outside
+// scenario-level ones (suite-before -> scenario-before -> step-before ->
+// step -> step-after -> scenario-after -> suite-after). This is useful
+// for large suites that share infra bring-up and a stable set of step
+// definitions across every scenario.
 type TestSuiteContext struct {
 	beforeSuiteHandlers []func()
 	afterSuiteHandlers  []func()
+
+	steps []*models.StepDefinition
+
+	beforeScenarioHandlers []BeforeScenarioHook
+	afterScenarioHandlers  []AfterScenarioHook
+	beforeStepHandlers     []BeforeStepHook
+	afterStepHandlers      []AfterStepHook
 }
 
 // BeforeSuite registers a function or method
@@ -86,6 +145,41 @@ func (ctx *TestSuiteContext) AfterSuite(fn func()) {
 	ctx.afterSuiteHandlers = append(ctx.afterSuiteHandlers, fn)
 }
 
+// Step registers a *StepDefinition the same way ScenarioContext.Step does,
+// except the definition applies to every scenario in the run rather than
+// only the scenarios passed through a single InitializeScenario call.
+func (ctx *TestSuiteContext) Step(expr, stepFunc interface{}) {
+	ctx.steps = append(ctx.steps, newStepDefinition(Any, expr, stepFunc))
+}
+
+// BeforeScenario registers a function or method to be run before every
+// scenario in the run, outside of any ScenarioContext.BeforeScenario
+// handlers registered for that scenario.
+func (ctx *TestSuiteContext) BeforeScenario(fn interface{}) {
+	ctx.beforeScenarioHandlers = append(ctx.beforeScenarioHandlers, mustBeforeScenarioHook(fn))
+}
+
+// AfterScenario registers a function or method to be run after every
+// scenario in the run, outside of any ScenarioContext.AfterScenario
+// handlers registered for that scenario.
+func (ctx *TestSuiteContext) AfterScenario(fn interface{}) {
+	ctx.afterScenarioHandlers = append(ctx.afterScenarioHandlers, mustAfterScenarioHook(fn))
+}
+
+// BeforeStep registers a function or method to be run before every step
+// in the run, outside of any ScenarioContext.BeforeStep handlers
+// registered for that scenario.
+func (ctx *TestSuiteContext) BeforeStep(fn interface{}) {
+	ctx.beforeStepHandlers = append(ctx.beforeStepHandlers, mustBeforeStepHook(fn))
+}
+
+// AfterStep registers a function or method to be run after every step in
+// the run, outside of any ScenarioContext.AfterStep handlers registered
+// for that scenario.
+func (ctx *TestSuiteContext) AfterStep(fn interface{}) {
+	ctx.afterStepHandlers = append(ctx.afterStepHandlers, mustAfterStepHook(fn))
+}
+
 // ScenarioContext allows various contexts
 // to register steps and event handlers.
 //
@@ -107,20 +201,35 @@ type ScenarioContext struct {
 // It is a good practice to restore the default state
 // before every scenario so it would be isolated from
 // any kind of state.
-func (ctx *ScenarioContext) BeforeScenario(fn func(sc *Scenario)) {
-	ctx.suite.beforeScenarioHandlers = append(ctx.suite.beforeScenarioHandlers, fn)
+//
+// fn may be either a plain func(sc *Scenario) or a contextualized
+// BeforeScenarioHook. The context.Context passed to
+// TestSuiteContext.RunScenario is the scenario's root context; the runner
+// does not create one of its own. The value returned by each
+// BeforeScenario hook is threaded into the next one, and finally into the
+// scenario's first step.
+func (ctx *ScenarioContext) BeforeScenario(fn interface{}) {
+	ctx.suite.beforeScenarioHandlers = append(ctx.suite.beforeScenarioHandlers, mustBeforeScenarioHook(fn))
 }
 
 // AfterScenario registers an function or method
 // to be run after every scenario.
-func (ctx *ScenarioContext) AfterScenario(fn func(sc *Scenario, err error)) {
-	ctx.suite.afterScenarioHandlers = append(ctx.suite.afterScenarioHandlers, fn)
+//
+// fn may be either a plain func(sc *Scenario, err error) or a
+// contextualized AfterScenarioHook, which is called with the context
+// propagated from the scenario's last step or hook.
+func (ctx *ScenarioContext) AfterScenario(fn interface{}) {
+	ctx.suite.afterScenarioHandlers = append(ctx.suite.afterScenarioHandlers, mustAfterScenarioHook(fn))
 }
 
 // BeforeStep registers a function or method
 // to be run before every step.
-func (ctx *ScenarioContext) BeforeStep(fn func(st *Step)) {
-	ctx.suite.beforeStepHandlers = append(ctx.suite.beforeStepHandlers, fn)
+//
+// fn may be either a plain func(st *Step) or a contextualized
+// BeforeStepHook, which is called with the context produced by the
+// previous step or hook and returns the context fed into the step.
+func (ctx *ScenarioContext) BeforeStep(fn interface{}) {
+	ctx.suite.beforeStepHandlers = append(ctx.suite.beforeStepHandlers, mustBeforeStepHook(fn))
 }
 
 // AfterStep registers an function or method
@@ -132,8 +241,112 @@ func (ctx *ScenarioContext) BeforeStep(fn func(st *Step)) {
 //
 // In some cases, for example when running a headless
 // browser, to take a screenshot after failure.
-func (ctx *ScenarioContext) AfterStep(fn func(st *Step, err error)) {
-	ctx.suite.afterStepHandlers = append(ctx.suite.afterStepHandlers, fn)
+//
+// fn may be either a plain func(st *Step, err error) or a contextualized
+// AfterStepHook, which is called with the context returned by the step
+// and returns the context propagated to the next step or hook.
+func (ctx *ScenarioContext) AfterStep(fn interface{}) {
+	ctx.suite.afterStepHandlers = append(ctx.suite.afterStepHandlers, mustAfterStepHook(fn))
+}
+
+// StepContext registers the same contextualized BeforeStepHook and
+// AfterStepHook used by ScenarioContext.BeforeStep/AfterStep, on the suite
+// of the ScenarioContext it was obtained from.
+//
+// It is obtained via ScenarioContext.StepContext. Its hooks are not scoped
+// to a single step definition: like hooks registered via
+// ScenarioContext.BeforeStep/AfterStep, they run around every step of the
+// scenario and are called with the context produced by the previous step
+// or hook.
+type StepContext struct {
+	suite *suite
+}
+
+// StepContext returns a StepContext bound to the same suite as ctx.
+func (ctx *ScenarioContext) StepContext() StepContext {
+	return StepContext{suite: ctx.suite}
+}
+
+// Before registers a contextualized hook to run before every step.
+func (sc StepContext) Before(h BeforeStepHook) {
+	sc.suite.beforeStepHandlers = append(sc.suite.beforeStepHandlers, h)
+}
+
+// After registers a contextualized hook to run after every step.
+func (sc StepContext) After(h AfterStepHook) {
+	sc.suite.afterStepHandlers = append(sc.suite.afterStepHandlers, h)
+}
+
+// mustBeforeScenarioHook normalizes fn into a BeforeScenarioHook, accepting
+// either the contextualized form or the legacy func(sc *Scenario).
+func mustBeforeScenarioHook(fn interface{}) BeforeScenarioHook {
+	switch h := fn.(type) {
+	case func(ctx context.Context, sc *Scenario) (context.Context, error):
+		return h
+	case BeforeScenarioHook:
+		return h
+	case func(sc *Scenario):
+		return func(ctx context.Context, sc *Scenario) (context.Context, error) {
+			h(sc)
+			return ctx, nil
+		}
+	default:
+		panic(fmt.Sprintf("unsupported BeforeScenario handler signature: %T", fn))
+	}
+}
+
+// mustAfterScenarioHook normalizes fn into an AfterScenarioHook, accepting
+// either the contextualized form or the legacy func(sc *Scenario, err error).
+func mustAfterScenarioHook(fn interface{}) AfterScenarioHook {
+	switch h := fn.(type) {
+	case func(ctx context.Context, sc *Scenario, err error) (context.Context, error):
+		return h
+	case AfterScenarioHook:
+		return h
+	case func(sc *Scenario, err error):
+		return func(ctx context.Context, sc *Scenario, err error) (context.Context, error) {
+			h(sc, err)
+			return ctx, nil
+		}
+	default:
+		panic(fmt.Sprintf("unsupported AfterScenario handler signature: %T", fn))
+	}
+}
+
+// mustBeforeStepHook normalizes fn into a BeforeStepHook, accepting either
+// the contextualized form or the legacy func(st *Step).
+func mustBeforeStepHook(fn interface{}) BeforeStepHook {
+	switch h := fn.(type) {
+	case func(ctx context.Context, st *Step) (context.Context, error):
+		return h
+	case BeforeStepHook:
+		return h
+	case func(st *Step):
+		return func(ctx context.Context, st *Step) (context.Context, error) {
+			h(st)
+			return ctx, nil
+		}
+	default:
+		panic(fmt.Sprintf("unsupported BeforeStep handler signature: %T", fn))
+	}
+}
+
+// mustAfterStepHook normalizes fn into an AfterStepHook, accepting either
+// the contextualized form or the legacy func(st *Step, err error).
+func mustAfterStepHook(fn interface{}) AfterStepHook {
+	switch h := fn.(type) {
+	case func(ctx context.Context, st *Step, err error) (context.Context, error):
+		return h
+	case AfterStepHook:
+		return h
+	case func(st *Step, err error):
+		return func(ctx context.Context, st *Step, err error) (context.Context, error) {
+			h(st, err)
+			return ctx, nil
+		}
+	default:
+		panic(fmt.Sprintf("unsupported AfterStep handler signature: %T", fn))
+	}
 }
 
 // Step allows to register a *StepDefinition in the
@@ -153,8 +366,24 @@ func (ctx *ScenarioContext) AfterStep(fn func(st *Step, err error)) {
 // - []byte
 // - *tspec.DocString
 // - *tspec.Table
+// - *big.Int, *big.Float or *apd.Decimal, parsed from the regex capture
+//   with a clear error on overflow, for precise arithmetic over large
+//   infrastructure numbers (quotas, byte counts)
+// - any struct pointer whose fields are populated from a *tspec.Table by
+//   matching header names against `tspec:"name"` field tags
+// - []T populated from a single-column *tspec.Table
+// - any other type T with a Transformer registered for it via
+//   RegisterTransformer
+//
+// The stepFunc may return nothing, a single error, or a single []string
+// for multistep. A handler with no results is treated as always
+// succeeding, which removes the boilerplate trailing "return nil" from
+// step definitions that rely on require/assert to fail via panic.
 //
-// The stepFunc need to return either an error or []string for multistep
+// stepFunc may also be contextualized: it may accept a leading
+// context.Context argument and return (context.Context, error). The
+// suite runner feeds it the context produced by the previous step or
+// hook and threads its returned context into the next one.
 //
 // Note that if there are two definitions which may match
 // the same step, then only the first matched handler
@@ -163,19 +392,168 @@ func (ctx *ScenarioContext) AfterStep(fn func(st *Step, err error)) {
 // If none of the *StepDefinition is matched, then
 // ErrUndefined error will be returned when
 // running steps.
+//
+// Step matches a step regardless of its Gherkin keyword. Use Given, When
+// or Then instead to restrict a definition to steps of that keyword.
 func (ctx *ScenarioContext) Step(expr, stepFunc interface{}) {
-	var regex *regexp.Regexp
+	ctx.step(Any, expr, stepFunc)
+}
+
+// Given registers a *StepDefinition the same way Step does, but the
+// definition will only match a step whose pickle keyword type is Given.
+func (ctx *ScenarioContext) Given(expr, stepFunc interface{}) {
+	ctx.step(Given, expr, stepFunc)
+}
+
+// When registers a *StepDefinition the same way Step does, but the
+// definition will only match a step whose pickle keyword type is When.
+func (ctx *ScenarioContext) When(expr, stepFunc interface{}) {
+	ctx.step(When, expr, stepFunc)
+}
+
+// Then registers a *StepDefinition the same way Step does, but the
+// definition will only match a step whose pickle keyword type is Then.
+func (ctx *ScenarioContext) Then(expr, stepFunc interface{}) {
+	ctx.step(Then, expr, stepFunc)
+}
+
+// And registers a *StepDefinition the same way Step does.
+//
+// Gherkin resolves an "And" step to the keyword type of whichever
+// Given/When/Then preceded it before it ever reaches a pickle, so there is
+// no distinct "And" pickle keyword type to restrict matching to; the
+// definition is registered against Any, same as Step.
+func (ctx *ScenarioContext) And(expr, stepFunc interface{}) {
+	ctx.step(Any, expr, stepFunc)
+}
+
+// But registers a *StepDefinition the same way Step does.
+//
+// As with And, Gherkin resolves a "But" step to the keyword type of
+// whichever Given/When/Then preceded it, so the definition is registered
+// against Any, same as Step.
+func (ctx *ScenarioContext) But(expr, stepFunc interface{}) {
+	ctx.step(Any, expr, stepFunc)
+}
+
+// PropertyChecks is the default number of generated cases a Property step
+// is run with. It is overridden per-run by the -tspec.rapid.checks flag.
+var PropertyChecks = 100
+
+// Property registers a step whose handler is a property: instead of
+// running once like a regular Step, the suite runner invokes it
+// PropertyChecks times (configurable via -tspec.rapid.checks) with
+// generated arguments produced by rapid, shrinking the failing case and
+// reporting the minimal one through the scenario's *testing.T on failure.
+// That report is rapid's own; it does not name the Gherkin scenario or
+// step the property ran under.
+//
+// fn must be a func whose first parameter is *rapid.T, used to declare
+// the generators for the case (e.g. rapid.Int(), rapid.StringMatching),
+// followed by zero or more of the regex-captured arguments Step accepts.
+// It may return nothing or a single error, same as Step.
+//
+// This lets specs express invariants such as "for any valid CIDR, the
+// created subnet is routable" without hand-rolling loops. Like any other
+// step, a Property step runs between the scenario's BeforeScenario and
+// AfterScenario hooks, but those hooks still run once per scenario, not
+// once per generated case: provision infra there, and let the property
+// check only the invariant over the per-case generated inputs.
+func (ctx *ScenarioContext) Property(expr, fn interface{}) {
+	v := reflect.ValueOf(fn)
+	typ := v.Type()
+	if typ.Kind() != reflect.Func {
+		panic(fmt.Sprintf("expected Property handler to be func, but got: %T", fn))
+	}
 
+	if typ.NumIn() == 0 || typ.In(0) != rapidTType {
+		panic(fmt.Sprintf("expected Property handler's first argument to be *rapid.T, but got: %s", typ))
+	}
+
+	switch typ.NumOut() {
+	case 0:
+	case 1:
+		if !typ.Out(0).Implements(errorInterface) {
+			panic(fmt.Sprintf("expected Property handler to return an error, but got: %s", typ.Out(0)))
+		}
+	default:
+		panic(fmt.Sprintf("expected Property handler to return zero or one value, but it has: %d", typ.NumOut()))
+	}
+
+	def := &models.StepDefinition{
+		StepDefinition: formatters.StepDefinition{
+			Handler: fn,
+			Expr:    mustCompileExpr(expr),
+		},
+		HandlerValue: v,
+		Keyword:      Any,
+		Property:     true,
+	}
+
+	ctx.suite.steps = append(ctx.suite.steps, def)
+}
+
+// rapidTType is the reflect.Type of *rapid.T, used to recognize Property
+// handlers.
+var rapidTType = reflect.TypeOf((*rapid.T)(nil))
+
+// transformers maps a step argument's target type to a user-registered
+// conversion func(string) (T, error), keyed by T. The step argument
+// dispatcher consults it for any declared argument type it doesn't
+// already know how to parse from a regex capture.
+var transformers = map[reflect.Type]reflect.Value{}
+
+// RegisterTransformer registers fn as the conversion used whenever a step
+// handler declares an argument of the type fn produces, letting projects
+// plug in parsers -- AWS ARNs, durations, and the like -- for types Step
+// doesn't support out of the box.
+//
+// fn must be a func(string) (T, error). target is a zero value of T,
+// used only to key the registration by type; its value is ignored.
+//
+// It will panic if fn is not a func(string) (T, error) matching target's
+// type, or if a transformer is already registered for that type.
+func RegisterTransformer(target interface{}, fn interface{}) {
+	targetTyp := reflect.TypeOf(target)
+
+	v := reflect.ValueOf(fn)
+	typ := v.Type()
+	if typ.Kind() != reflect.Func || typ.NumIn() != 1 || typ.In(0).Kind() != reflect.String ||
+		typ.NumOut() != 2 || typ.Out(0) != targetTyp || !typ.Out(1).Implements(errorInterface) {
+		panic(fmt.Sprintf("expected fn to be func(string) (%s, error), but got: %T", targetTyp, fn))
+	}
+
+	if _, exists := transformers[targetTyp]; exists {
+		panic(fmt.Sprintf("a Transformer is already registered for type: %s", targetTyp))
+	}
+
+	transformers[targetTyp] = v
+}
+
+// mustCompileExpr compiles expr the same way Step does, panicking on an
+// unsupported type.
+func mustCompileExpr(expr interface{}) *regexp.Regexp {
 	switch t := expr.(type) {
 	case *regexp.Regexp:
-		regex = t
+		return t
 	case string:
-		regex = regexp.MustCompile(t)
+		return regexp.MustCompile(t)
 	case []byte:
-		regex = regexp.MustCompile(string(t))
+		return regexp.MustCompile(string(t))
 	default:
 		panic(fmt.Sprintf("expecting expr to be a *regexp.Regexp or a string, got type: %T", expr))
 	}
+}
+
+func (ctx *ScenarioContext) step(keyword Keyword, expr, stepFunc interface{}) {
+	ctx.suite.steps = append(ctx.suite.steps, newStepDefinition(keyword, expr, stepFunc))
+}
+
+// newStepDefinition validates expr and stepFunc and builds the
+// *models.StepDefinition shared by ScenarioContext and TestSuiteContext
+// step registration.
+func newStepDefinition(keyword Keyword, expr, stepFunc interface{}) *models.StepDefinition {
+	regex := mustCompileExpr(expr)
 
 	v := reflect.ValueOf(stepFunc)
 	typ := v.Type()
@@ -183,32 +561,218 @@ func (ctx *ScenarioContext) Step(expr, stepFunc interface{}) {
 		panic(fmt.Sprintf("expected handler to be func, but got: %T", stepFunc))
 	}
 
-	if typ.NumOut() != 1 {
-		panic(fmt.Sprintf("expected handler to return only one value, but it has: %d", typ.NumOut()))
-	}
-
 	def := &models.StepDefinition{
 		StepDefinition: formatters.StepDefinition{
 			Handler: stepFunc,
 			Expr:    regex,
 		},
 		HandlerValue: v,
+		Keyword:      keyword,
+	}
+
+	// A contextualized handler leads with a context.Context argument and
+	// returns (context.Context, error) instead of the plain error/[]string
+	// forms below.
+	if typ.NumIn() > 0 && typ.In(0) == contextType {
+		if typ.NumOut() != 2 || typ.Out(0) != contextType || !typ.Out(1).Implements(errorInterface) {
+			panic(fmt.Sprintf("expected contextualized handler to return (context.Context, error), but got: %s", typ))
+		}
+
+		def.HasContext = true
+		return def
+	}
+
+	// A handler with no results is treated as always succeeding (nil
+	// error), sparing step definitions the ubiquitous trailing "return nil"
+	// when they rely on require/assert to fail the step via panic.
+	switch typ.NumOut() {
+	case 0:
+		return def
+	case 1:
+		// handled below
+	default:
+		panic(fmt.Sprintf("expected handler to return zero or one value, but it has: %d", typ.NumOut()))
 	}
 
-	typ = typ.Out(0)
-	switch typ.Kind() {
+	outTyp := typ.Out(0)
+	switch outTyp.Kind() {
 	case reflect.Interface:
-		if !typ.Implements(errorInterface) {
-			panic(fmt.Sprintf("expected handler to return an error, but got: %s", typ.Kind()))
+		if !outTyp.Implements(errorInterface) {
+			panic(fmt.Sprintf("expected handler to return an error, but got: %s", outTyp.Kind()))
 		}
 	case reflect.Slice:
-		if typ.Elem().Kind() != reflect.String {
-			panic(fmt.Sprintf("expected handler to return []string for multistep, but got: []%s", typ.Kind()))
+		if outTyp.Elem().Kind() != reflect.String {
+			panic(fmt.Sprintf("expected handler to return []string for multistep, but got: []%s", outTyp.Kind()))
 		}
 		def.Nested = true
 	default:
-		panic(fmt.Sprintf("expected handler to return an error or []string, but got: %s", typ.Kind()))
+		panic(fmt.Sprintf("expected handler to return an error or []string, but got: %s", outTyp.Kind()))
 	}
 
-	ctx.suite.steps = append(ctx.suite.steps, def)
+	return def
+}
+
+// contextType is the reflect.Type of context.Context, used to detect
+// contextualized hook and step handlers.
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// suiteRegistrar is the subset of ScenarioContext and TestSuiteContext
+// that RegisterSuite wires generated hooks and step definitions into.
+type suiteRegistrar interface {
+	Step(expr, stepFunc interface{})
+	BeforeScenario(fn interface{})
+	AfterScenario(fn interface{})
+	BeforeStep(fn interface{})
+	AfterStep(fn interface{})
+}
+
+// RegisterSuite reflects over the exported methods of suiteStruct (a
+// struct or pointer to struct) and wires them up automatically: methods
+// whose names begin with Before/After followed by Scenario or Step
+// (BeforeScenario, AfterScenarioCleanup, BeforeStepLog, ...) become the
+// matching contextualized hook, and every other exported method listed in
+// a companion `StepDefs() map[string]interface{}` method (mapping the
+// method name to its step regex) becomes a step definition. StepDefs may
+// be declared with either a value or a pointer receiver.
+//
+// suiteStruct is never invoked directly -- RegisterSuite instantiates a
+// fresh value of its type via reflect.New for every scenario and stashes
+// it on the scenario's context, so its fields (Terraform options, output
+// caches, module addresses) provide state isolated to that scenario
+// instead of today's package-level globals. This mirrors the
+// strongly-typed suite pattern popularized by gocuke.
+//
+// BeforeSuite/AfterSuite methods are not handled here: RegisterSuite
+// creates one instance per scenario, so suite-wide setup belongs on
+// TestSuiteContext.BeforeSuite/AfterSuite directly instead.
+func (ctx *ScenarioContext) RegisterSuite(suiteStruct interface{}) {
+	registerSuite(ctx, suiteStruct)
+}
+
+// RegisterSuite is the TestSuiteContext equivalent of
+// ScenarioContext.RegisterSuite: the generated step definitions and hooks
+// apply to every scenario in the run. See ScenarioContext.RegisterSuite
+// for the wiring rules.
+func (ctx *TestSuiteContext) RegisterSuite(suiteStruct interface{}) {
+	registerSuite(ctx, suiteStruct)
+}
+
+// suiteInstanceKey is the context.Context key a RegisterSuite instance is
+// stashed under, scoped by the suite struct's type so that nested or
+// composed RegisterSuite calls for distinct structs don't collide.
+type suiteInstanceKey struct{ typ reflect.Type }
+
+func registerSuite(reg suiteRegistrar, suiteStruct interface{}) {
+	typ := reflect.TypeOf(suiteStruct)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("RegisterSuite expects a struct or a pointer to struct, but got: %T", suiteStruct))
+	}
+
+	key := suiteInstanceKey{typ: typ}
+
+	reg.BeforeScenario(func(ctx context.Context, sc *Scenario) (context.Context, error) {
+		return context.WithValue(ctx, key, reflect.New(typ)), nil
+	})
+
+	// StepDefs is looked up on a fresh pointer instance, not suiteStruct
+	// itself, so a pointer-receiver StepDefs() is found even when
+	// RegisterSuite is called with a value (RegisterSuite(MySuite{})).
+	stepDefs := map[string]interface{}{}
+	if provider, ok := reflect.New(typ).Interface().(interface{ StepDefs() map[string]interface{} }); ok {
+		stepDefs = provider.StepDefs()
+	}
+
+	ptrTyp := reflect.PtrTo(typ)
+	for i := 0; i < ptrTyp.NumMethod(); i++ {
+		method := ptrTyp.Method(i)
+
+		switch {
+		case method.Name == "StepDefs":
+			continue
+		case strings.HasPrefix(method.Name, "BeforeSuite"), strings.HasPrefix(method.Name, "AfterSuite"):
+			continue
+		case strings.HasPrefix(method.Name, "BeforeScenario"):
+			m := method
+			reg.BeforeScenario(func(ctx context.Context, sc *Scenario) (context.Context, error) {
+				instance := mustSuiteInstance(ctx, key, m.Name)
+				return ctx, callSuiteMethod(m, instance, reflect.ValueOf(sc))
+			})
+		case strings.HasPrefix(method.Name, "AfterScenario"):
+			m := method
+			reg.AfterScenario(func(ctx context.Context, sc *Scenario, err error) (context.Context, error) {
+				instance := mustSuiteInstance(ctx, key, m.Name)
+				return ctx, callSuiteMethod(m, instance, reflect.ValueOf(sc), reflect.ValueOf(&err).Elem())
+			})
+		case strings.HasPrefix(method.Name, "BeforeStep"):
+			m := method
+			reg.BeforeStep(func(ctx context.Context, st *Step) (context.Context, error) {
+				instance := mustSuiteInstance(ctx, key, m.Name)
+				return ctx, callSuiteMethod(m, instance, reflect.ValueOf(st))
+			})
+		case strings.HasPrefix(method.Name, "AfterStep"):
+			m := method
+			reg.AfterStep(func(ctx context.Context, st *Step, err error) (context.Context, error) {
+				instance := mustSuiteInstance(ctx, key, m.Name)
+				return ctx, callSuiteMethod(m, instance, reflect.ValueOf(st), reflect.ValueOf(&err).Elem())
+			})
+		default:
+			expr, ok := stepDefs[method.Name]
+			if !ok {
+				continue
+			}
+			reg.Step(expr, bindSuiteStep(key, method))
+		}
+	}
+}
+
+// mustSuiteInstance retrieves the per-scenario suite instance stashed by
+// registerSuite's BeforeScenario hook, which always runs first since it is
+// registered before any other RegisterSuite hook or step.
+func mustSuiteInstance(ctx context.Context, key suiteInstanceKey, methodName string) reflect.Value {
+	instance, ok := ctx.Value(key).(reflect.Value)
+	if !ok {
+		panic(fmt.Sprintf("tspec: no %s instance on context calling %s; RegisterSuite's instantiation hook must run first", key.typ, methodName))
+	}
+	return instance
+}
+
+// callSuiteMethod invokes method on instance with the given arguments and
+// returns the first non-nil error among its results, if any.
+func callSuiteMethod(method reflect.Method, instance reflect.Value, args ...reflect.Value) error {
+	results := method.Func.Call(append([]reflect.Value{instance}, args...))
+	for _, r := range results {
+		if r.Type().Implements(errorInterface) && !r.IsNil() {
+			return r.Interface().(error)
+		}
+	}
+	return nil
+}
+
+// bindSuiteStep builds a contextualized step handler for method, preserving
+// its original (non-receiver) parameter types so the suite runner's regex
+// argument coercion in buildArgs keeps working unmodified.
+func bindSuiteStep(key suiteInstanceKey, method reflect.Method) interface{} {
+	numIn := method.Type.NumIn() - 1 // drop the receiver
+
+	in := make([]reflect.Type, numIn+1)
+	in[0] = contextType
+	for i := 0; i < numIn; i++ {
+		in[i+1] = method.Type.In(i + 1)
+	}
+
+	fnTyp := reflect.FuncOf(in, []reflect.Type{contextType, errorInterface}, false)
+
+	return reflect.MakeFunc(fnTyp, func(args []reflect.Value) []reflect.Value {
+		instance := mustSuiteInstance(args[0].Interface().(context.Context), key, method.Name)
+
+		errVal := reflect.Zero(errorInterface)
+		if err := callSuiteMethod(method, instance, args[1:]...); err != nil {
+			errVal = reflect.ValueOf(err)
+		}
+
+		return []reflect.Value{args[0], errVal}
+	}).Interface()
 }
@@ -0,0 +1,58 @@
+package tspec
+
+import (
+	"context"
+	"testing"
+)
+
+// pointerStepDefsSuite declares StepDefs with a pointer receiver, to prove
+// RegisterSuite finds it even when passed a value (RegisterSuite(Suite{})).
+type pointerStepDefsSuite struct{}
+
+func (s *pointerStepDefsSuite) StepDefs() map[string]interface{} {
+	return map[string]interface{}{"ARegisteredStep": `^a registered step$`}
+}
+
+func (s *pointerStepDefsSuite) ARegisteredStep() error { return nil }
+
+func TestRegisterSuiteFindsPointerReceiverStepDefsFromValue(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	sc.RegisterSuite(pointerStepDefsSuite{})
+
+	steps := []*Step{{Text: "a registered step"}}
+	if _, err := sc.suite.runScenario(context.Background(), &Scenario{}, steps); err != nil {
+		t.Fatalf("expected the pointer-receiver StepDefs to register the step, got: %v", err)
+	}
+}
+
+// prefixHookSuite declares hooks whose names begin with, but aren't equal
+// to, the canonical Before/AfterScenario/Step names.
+type prefixHookSuite struct{ called []string }
+
+func (s *prefixHookSuite) BeforeScenarioSetup(sc *Scenario) {
+	s.called = append(s.called, "before-scenario")
+}
+func (s *prefixHookSuite) AfterStepCleanup(st *Step, err error) {
+	s.called = append(s.called, "after-step")
+}
+
+func TestRegisterSuiteMatchesHookNamesByPrefix(t *testing.T) {
+	sc := &ScenarioContext{suite: &suite{}}
+
+	sc.RegisterSuite(&prefixHookSuite{})
+
+	// One BeforeScenario hook instantiates the per-scenario suite value;
+	// BeforeScenarioSetup should register a second one alongside it.
+	if len(sc.suite.beforeScenarioHandlers) != 2 {
+		t.Fatalf("expected BeforeScenarioSetup to be recognized as a BeforeScenario hook, got %d before-scenario handlers", len(sc.suite.beforeScenarioHandlers))
+	}
+	if len(sc.suite.afterStepHandlers) != 1 {
+		t.Fatalf("expected AfterStepCleanup to be recognized as an AfterStep hook, got %d after-step handlers", len(sc.suite.afterStepHandlers))
+	}
+
+	steps := []*Step{}
+	if _, err := sc.suite.runScenario(context.Background(), &Scenario{}, steps); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+}